@@ -0,0 +1,124 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// ackDevice replies to every command with the board's "$$$" acknowledgement
+// and, after each impedance-enable command ("z" with the P flag set to '1'),
+// also emits impedanceSampleCount SDK frames right after the "$$$" bytes -
+// enough to drive both
+// ConfigureChannel (which only needs the ack) and MeasureImpedance (which
+// needs the ack followed by sampled packets).
+type ackDevice struct {
+	buf     bytes.Buffer
+	cmds    [][]byte
+	channel int32
+	seq     uint8
+}
+
+func (d *ackDevice) Read(p []byte) (int, error) {
+	if d.buf.Len() == 0 {
+		return 0, nil
+	}
+	return d.buf.Read(p)
+}
+
+func (d *ackDevice) Write(p []byte) (int, error) {
+	cmd := append([]byte(nil), p...)
+	d.cmds = append(d.cmds, cmd)
+	d.buf.Write([]byte{Command["init"], Command["init"], Command["init"]})
+	if len(cmd) > 0 && cmd[0] == 'z' && len(cmd) >= 3 && cmd[2] == '1' {
+		for i := 0; i < impedanceSampleCount; i++ {
+			d.buf.Write(d.nextFrame())
+		}
+	}
+	return len(p), nil
+}
+
+func (d *ackDevice) Close() error { return nil }
+
+// nextFrame builds an SDK frame with every channel set to d.channel, so a
+// test can assert the exact impedance MeasureImpedance computes from it.
+func (d *ackDevice) nextFrame() []byte {
+	frame := make([]byte, packetSize)
+	frame[0] = Command["header"]
+	frame[1] = d.seq
+	d.seq++
+	for ch := 0; ch < 8; ch++ {
+		off := 2 + ch*3
+		frame[off] = byte(d.channel >> 16)
+		frame[off+1] = byte(d.channel >> 8)
+		frame[off+2] = byte(d.channel)
+	}
+	frame[packetSize-1] = Command["footer"]
+	return frame
+}
+
+func TestConfigureChannel(t *testing.T) {
+	dev, ad := newAckHarness(0)
+
+	cfg := ChannelConfig{Gain: Gain24, Input: InputNormal, SRB2: true}
+	if err := dev.ConfigureChannel(3, cfg); err != nil {
+		t.Fatalf("ConfigureChannel: %v", err)
+	}
+
+	if len(ad.cmds) != 1 {
+		t.Fatalf("got %d commands written, want 1", len(ad.cmds))
+	}
+	want := []byte{'x', '3', '0', byte('0' + Gain24), byte('0' + InputNormal), '0', '1', '0', 'X'}
+	if !bytes.Equal(ad.cmds[0], want) {
+		t.Errorf("command = %v, want %v", ad.cmds[0], want)
+	}
+}
+
+func TestMeasureImpedance(t *testing.T) {
+	const rawValue = int32(1000)
+	dev, ad := newAckHarness(rawValue)
+
+	ohms, err := dev.MeasureImpedance(3)
+	if err != nil {
+		t.Fatalf("MeasureImpedance: %v", err)
+	}
+
+	want := float64(rawValue) * adcScaleMicrovolts * 1e-6 / impedanceTestCurrentAmps
+	if math.Abs(ohms-want) > 1e-9 {
+		t.Errorf("MeasureImpedance(3) = %g, want %g", ohms, want)
+	}
+
+	if len(ad.cmds) != 2 {
+		t.Fatalf("got %d commands written, want 2 (enable, disable)", len(ad.cmds))
+	}
+	enable := []byte{'z', '3', '1', '1', 'Z'}
+	disable := []byte{'z', '3', '0', '0', 'Z'}
+	if !bytes.Equal(ad.cmds[0], enable) {
+		t.Errorf("first command = %v, want %v", ad.cmds[0], enable)
+	}
+	if !bytes.Equal(ad.cmds[1], disable) {
+		t.Errorf("second command = %v, want %v (impedance test left enabled)", ad.cmds[1], disable)
+	}
+}
+
+func newAckHarness(channel int32) (*Device, *ackDevice) {
+	ad := &ackDevice{channel: channel}
+	return &Device{r: ad, w: ad, c: ad}, ad
+}
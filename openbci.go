@@ -18,12 +18,11 @@
 package openbci
 
 import (
+	"context"
 	"crypto/rand"
 	"io"
 	"log"
 	"time"
-
-	"github.com/tarm/serial"
 )
 
 var Command map[string]byte = map[string]byte{
@@ -35,27 +34,33 @@ var Command map[string]byte = map[string]byte{
 	"init":   '\x24',
 }
 
-func NewDevice(location string, baud int, readTimeout time.Duration) (io.ReadWriteCloser, error) {
-	conf := &serial.Config{
-		Name:        location,
-		Baud:        baud,
-		ReadTimeout: readTimeout,
-	}
-	conn, err := serial.OpenPort(conf)
-	if err != nil {
+// NewDevice opens t and wraps it in a Device. t may be any Transport,
+// built-in or custom, which lets tests drive the driver from an in-process
+// pipe without needing hardware.
+func NewDevice(t Transport) (io.ReadWriteCloser, error) {
+	if err := t.Open(); err != nil {
 		return nil, err
 	}
 	return &Device{
-		r: conn,
-		w: conn,
-		c: conn,
+		r: t,
+		w: t,
+		c: t,
 	}, nil
 }
 
+// Device wraps a single Transport's byte stream. Read, ResetContext,
+// ConfigureChannel, and MeasureImpedance all consume that same stream, so
+// at most one of them may be in flight at a time; in particular, a
+// caller-owned packet stream (e.g. NewPacketStream(device)) must be
+// stopped before calling ResetContext, ConfigureChannel, or
+// MeasureImpedance on the same Device, and vice versa.
 type Device struct {
 	r io.Reader
 	w io.Writer
 	c io.Closer
+
+	info    BoardInfo
+	scanner *Scanner
 }
 
 func (d *Device) Read(buf []byte) (int, error) {
@@ -77,12 +82,20 @@ func isReset(buf []byte) bool {
 
 func (d *Device) Write(buf []byte) (int, error) {
 	if isReset(buf) {
-		n, err := d.reset(buf)
-		if err != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultResetTimeout)
+		defer cancel()
+		if err := d.ResetContext(ctx); err != nil {
 			return 0, err
 		}
-		return n, nil
+		return len(buf), nil
 	}
+	return d.writeRaw(buf)
+}
+
+// writeRaw writes buf directly to the transport, bypassing the isReset
+// check in Write. The reset handshake in reset.go uses this to send its
+// own stop/reset/start bytes without re-triggering itself.
+func (d *Device) writeRaw(buf []byte) (int, error) {
 	log.Printf("Writing %v to device", buf)
 	n, err := d.w.Write(buf)
 	time.Sleep(50 * time.Millisecond)
@@ -92,49 +105,6 @@ func (d *Device) Write(buf []byte) (int, error) {
 	return n, nil
 }
 
-func (d *Device) reset(buf []byte) (n int, err error) {
-	var (
-		n0, n1, n2, idx int
-		init_array      [3]byte
-		scrolling       [3]byte
-	)
-	buf = make([]byte, 1)
-	n0, err = d.Write([]byte{Command["stop"]})
-	if err != nil {
-		return 0, err
-	}
-	n += n0
-	time.Sleep(10 * time.Millisecond)
-	log.Printf("Writing %v to device", Command["reset"])
-	n1, err = d.Write([]byte{Command["reset"]})
-	if err != nil {
-		return n, err
-	}
-	n += n1
-	time.Sleep(10 * time.Millisecond)
-
-	init_array = [3]byte{Command["init"], Command["init"], Command["init"]}
-
-	for {
-		_, err := d.Read(buf)
-		if err == io.EOF {
-			continue
-		} else if err != nil {
-			return n, err
-		}
-		scrolling[idx%3] = buf[0]
-		idx++
-		if scrolling == init_array {
-			n2, err = d.Write([]byte{Command["start"]})
-			if err != nil {
-				return n, err
-			}
-			n += n2
-			return n, nil
-		}
-	}
-}
-
 func (d *Device) Close() error {
 	err := d.c.Close()
 	if err != nil {
@@ -145,51 +115,69 @@ func (d *Device) Close() error {
 
 func NewMockDevice() *MockDevice { return &MockDevice{on: false} }
 
+// mockBannerText is the startup banner MockDevice replies with after a
+// reset, standing in for the text a real Cyton board prints before its
+// "$$$" terminator.
+const mockBannerText = "OpenBCI V3 8-16 channel\n"
+
+// mockBanner returns the banner bytes MockDevice emits after a reset:
+// mockBannerText followed by three Command["init"] bytes ("$$$").
+func mockBanner() []byte {
+	b := []byte(mockBannerText)
+	return append(b, Command["init"], Command["init"], Command["init"])
+}
+
 type MockDevice struct {
-	on          bool
-	seqcounter  uint8
-	datacounter uint8
-	readstate   uint8
+	on    bool
+	seq   uint8
+	frame []byte
+	pos   int
+
+	banner    []byte
+	bannerPos int
+}
+
+// nextFrame builds one byte-accurate 33-byte SDK frame (header, sample
+// number, 8 random 24-bit channels, 3 random 16-bit aux samples, footer),
+// advancing the sequence counter as a real board would.
+func (md *MockDevice) nextFrame() []byte {
+	frame := make([]byte, packetSize)
+	frame[0] = Command["header"]
+	frame[1] = md.seq
+	md.seq++
+	rand.Read(frame[2 : packetSize-1])
+	frame[packetSize-1] = Command["footer"]
+	return frame
 }
 
 func (md *MockDevice) Read(p []byte) (n int, err error) {
-	var b int
-	if md.on {
+	if md.bannerPos < len(md.banner) {
 		for idx := range p {
-			switch md.readstate {
-			case 0:
-				p[idx] = Command["footer"]
-				md.readstate++
-				b++
-			case 1:
-				p[idx] = Command["header"]
-				md.readstate++
-				b++
-			case 2:
-				p[idx] = md.seqcounter
-				md.readstate++
-				b++
-			case 3:
-				buf := make([]byte, 1)
-				rand.Read(buf)
-				p[idx] = buf[0]
-				b++
-				md.datacounter++
-				if md.datacounter == 30 {
-					md.readstate++
-					md.datacounter = 0
-				}
-			case 4:
-				p[idx] = Command["footer"]
-				md.readstate = 1
-				md.seqcounter++
-				b++
-				time.Sleep(time.Millisecond * 25)
+			if md.bannerPos == len(md.banner) {
+				break
 			}
-
+			p[idx] = md.banner[md.bannerPos]
+			md.bannerPos++
+			n++
+		}
+		return n, nil
+	}
+	if !md.on {
+		return 0, nil
+	}
+	for idx := range p {
+		if md.pos == 0 {
+			md.frame = md.nextFrame()
+		}
+		p[idx] = md.frame[md.pos]
+		md.pos++
+		n++
+		if md.pos == len(md.frame) {
+			md.pos = 0
+			time.Sleep(time.Millisecond * 25)
 		}
 	}
-	return b, nil
+	return n, nil
 }
 
 func (md *MockDevice) Write(p []byte) (n int, err error) {
@@ -202,6 +190,10 @@ func (md *MockDevice) Write(p []byte) (n int, err error) {
 			md.on = true
 		case Command["stop"]:
 			md.on = false
+		case Command["reset"]:
+			md.on = false
+			md.banner = mockBanner()
+			md.bannerPos = 0
 		}
 	}
 	return len(p), nil
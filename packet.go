@@ -0,0 +1,146 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+// packetSize is the length in bytes of a single Cyton SDK frame: header (1),
+// sample number (1), 8 channels at 24 bits (24), 3 aux samples at 16 bits
+// (6), footer (1).
+const packetSize = 33
+
+// Packet is a single decoded sample frame from the OpenBCI board.
+type Packet struct {
+	SeqNum    uint8
+	Channels  [8]int32
+	AuxData   [3]int16
+	Timestamp time.Time
+}
+
+// Scanner reads a raw OpenBCI byte stream and decodes it into Packets,
+// resynchronizing on the 0xA0/0xC0 header/footer pair whenever a frame is
+// malformed. It is modeled on bufio.Scanner: call Scan in a loop, then
+// Packet to retrieve the most recently decoded value.
+type Scanner struct {
+	r       *bufio.Reader
+	pkt     Packet
+	err     error
+	dropped uint64
+}
+
+// NewScanner returns a Scanner that reads frames from r.
+func NewScanner(r io.Reader) *Scanner {
+	return &Scanner{r: bufio.NewReaderSize(r, packetSize*4)}
+}
+
+// Scan advances the Scanner to the next well-formed Packet, discarding any
+// malformed frames it encounters along the way. It returns false when no
+// more packets can be decoded, either because of a read error or because
+// the underlying reader is exhausted; call Err to distinguish the two.
+func (s *Scanner) Scan() bool {
+	if s.err != nil {
+		return false
+	}
+	for {
+		b, err := s.r.ReadByte()
+		if err != nil {
+			s.err = err
+			return false
+		}
+		if b != Command["header"] {
+			continue
+		}
+		frame := make([]byte, packetSize-1)
+		if _, err := io.ReadFull(s.r, frame); err != nil {
+			s.err = err
+			return false
+		}
+		if frame[packetSize-2] != Command["footer"] {
+			s.dropped++
+			continue
+		}
+		s.pkt = decodePacket(frame)
+		return true
+	}
+}
+
+// Packet returns the most recent Packet produced by a call to Scan.
+func (s *Scanner) Packet() Packet { return s.pkt }
+
+// Err returns the first non-EOF error encountered while scanning.
+func (s *Scanner) Err() error {
+	if s.err == io.EOF {
+		return nil
+	}
+	return s.err
+}
+
+// Dropped returns the number of malformed frames discarded since the
+// Scanner was created.
+func (s *Scanner) Dropped() uint64 { return s.dropped }
+
+// decodePacket decodes a frame with the leading header byte already
+// consumed: sample number, 8 big-endian 24-bit two's-complement channels,
+// 3 big-endian 16-bit aux samples, and the trailing footer byte.
+func decodePacket(frame []byte) Packet {
+	var pkt Packet
+	pkt.SeqNum = frame[0]
+	for ch := 0; ch < 8; ch++ {
+		off := 1 + ch*3
+		pkt.Channels[ch] = decode24(frame[off], frame[off+1], frame[off+2])
+	}
+	for a := 0; a < 3; a++ {
+		off := 25 + a*2
+		pkt.AuxData[a] = int16(binary.BigEndian.Uint16(frame[off : off+2]))
+	}
+	pkt.Timestamp = time.Now()
+	return pkt
+}
+
+// decode24 sign-extends a big-endian 24-bit two's-complement sample into
+// an int32.
+func decode24(b0, b1, b2 byte) int32 {
+	v := int32(b0)<<16 | int32(b1)<<8 | int32(b2)
+	if v&0x800000 != 0 {
+		v |= -1 << 24
+	}
+	return v
+}
+
+// NewPacketStream decodes r on its own goroutine and delivers each frame
+// as a Packet on the returned channel. The channel is closed when r
+// returns a non-nil error, including io.EOF. If r is a *Device, the
+// returned stream and the Device's own ResetContext/ConfigureChannel/
+// MeasureImpedance methods read the same underlying bytes; don't run them
+// concurrently against the same Device.
+func NewPacketStream(r io.Reader) <-chan Packet {
+	out := make(chan Packet)
+	go func() {
+		defer close(out)
+		sc := NewScanner(r)
+		for sc.Scan() {
+			out <- sc.Packet()
+		}
+	}()
+	return out
+}
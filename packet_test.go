@@ -0,0 +1,76 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestScannerDecodesMockDeviceFrames(t *testing.T) {
+	md := NewMockDevice()
+	if _, err := md.Write([]byte{Command["start"]}); err != nil {
+		t.Fatalf("Write(start): %v", err)
+	}
+
+	sc := NewScanner(md)
+	var lastSeq uint8
+	for i := 0; i < 5; i++ {
+		if !sc.Scan() {
+			t.Fatalf("Scan() failed on packet %d: %v", i, sc.Err())
+		}
+		pkt := sc.Packet()
+		if i > 0 && pkt.SeqNum != lastSeq+1 {
+			t.Errorf("packet %d: SeqNum = %d, want %d", i, pkt.SeqNum, lastSeq+1)
+		}
+		lastSeq = pkt.SeqNum
+	}
+	if sc.Dropped() != 0 {
+		t.Errorf("Dropped() = %d, want 0 for well-formed frames", sc.Dropped())
+	}
+}
+
+func TestScannerResyncsOnMalformedFrame(t *testing.T) {
+	var buf bytes.Buffer
+	buf.WriteByte(0x01)               // noise before the first header
+	buf.WriteByte(Command["header"])  // start of a malformed frame
+	buf.Write(make([]byte, packetSize-2)) // 31 arbitrary bytes
+	buf.WriteByte(0x00)               // footer byte, deliberately wrong
+
+	wantSeq := uint8(7)
+	wantChannels := [8]int32{1, -1, 0, 0, 0, 0, 0, 0}
+	buf.WriteByte(Command["header"])
+	buf.WriteByte(wantSeq)
+	buf.Write([]byte{0x00, 0x00, 0x01}) // channel 0 = 1
+	buf.Write([]byte{0xff, 0xff, 0xff}) // channel 1 = -1
+	buf.Write(make([]byte, 6*3))        // channels 2-7 = 0
+	buf.Write(make([]byte, 6))          // aux 0-2 = 0
+	buf.WriteByte(Command["footer"])
+
+	sc := NewScanner(&buf)
+	if !sc.Scan() {
+		t.Fatalf("Scan() failed: %v", sc.Err())
+	}
+	got := sc.Packet()
+	if got.SeqNum != wantSeq || got.Channels != wantChannels {
+		t.Errorf("Packet() = %+v, want SeqNum=%d Channels=%v", got, wantSeq, wantChannels)
+	}
+	if sc.Dropped() != 1 {
+		t.Errorf("Dropped() = %d, want 1", sc.Dropped())
+	}
+}
@@ -0,0 +1,183 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package record
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/kevinjos/openbci-driver"
+)
+
+const (
+	numChannels  = 8
+	digitalMin   = -8388608
+	digitalMax   = 8388607
+	mainHdrBytes = 256
+	sigHdrBytes  = 256
+)
+
+// BDFWriter batches decoded Packets into fixed-length BDF+ data records
+// and writes a BIOSEMI-format file. BDF's native sample width is 24-bit
+// signed, the same word width as the OpenBCI ADS1299, so channel values
+// are written through unscaled.
+type BDFWriter struct {
+	w   io.Writer
+	cfg Config
+
+	buf       [numChannels][]int32
+	nBuffered int
+	nRecords  int
+}
+
+// NewBDFWriter writes a BDF+ header built from cfg to w and returns a
+// BDFWriter ready to accept Packets. Samples are batched into one-second
+// data records at cfg.SampleRate Hz and flushed as each record fills;
+// call Close to flush any partial final record.
+func NewBDFWriter(w io.Writer, cfg Config) (*BDFWriter, error) {
+	if cfg.SampleRate <= 0 {
+		return nil, fmt.Errorf("record: SampleRate must be positive, got %d", cfg.SampleRate)
+	}
+	bw := &BDFWriter{w: w, cfg: cfg}
+	for ch := range bw.buf {
+		bw.buf[ch] = make([]int32, 0, cfg.SampleRate)
+	}
+	if err := bw.writeHeader(); err != nil {
+		return nil, err
+	}
+	return bw, nil
+}
+
+func (bw *BDFWriter) writeHeader() error {
+	cfg := bw.cfg
+	var main [mainHdrBytes]byte
+	main[0] = 0xFF // BDF's version field is 0xFF + "BIOSEMI"; this leading
+	// non-ASCII byte is how readers tell a BDF file apart from EDF.
+	copy(main[1:8], "BIOSEMI")
+	copy(main[8:88], padRight(cfg.PatientID, 80))
+	copy(main[88:168], padRight(cfg.RecordingID, 80))
+	copy(main[168:176], padRight(cfg.StartTime.Format("02.01.06"), 8))
+	copy(main[176:184], padRight(cfg.StartTime.Format("15.04.05"), 8))
+	copy(main[184:192], padRight(fmt.Sprintf("%d", mainHdrBytes+numChannels*sigHdrBytes), 8))
+	copy(main[192:236], padRight("BDF+C", 44)) // continuous recording marker, required by the BDF+/EDF+ spec
+	copy(main[236:244], padRight("-1", 8))     // number of data records, patched on Close if possible
+	copy(main[244:252], padRight("1", 8))  // duration of a data record, in seconds
+	copy(main[252:256], padRight(fmt.Sprintf("%d", numChannels), 4))
+	if _, err := bw.w.Write(main[:]); err != nil {
+		return err
+	}
+
+	fields := [9][numChannels]string{}
+	for ch := 0; ch < numChannels; ch++ {
+		fields[0][ch] = fmt.Sprintf("ch%d", ch)
+		fields[1][ch] = "active electrode"
+		fields[2][ch] = "uV"
+		fields[3][ch] = fmt.Sprintf("%g", cfg.PhysicalMin)
+		fields[4][ch] = fmt.Sprintf("%g", cfg.PhysicalMax)
+		fields[5][ch] = fmt.Sprintf("%d", digitalMin)
+		fields[6][ch] = fmt.Sprintf("%d", digitalMax)
+		fields[7][ch] = cfg.Prefilter
+		fields[8][ch] = fmt.Sprintf("%d", cfg.SampleRate)
+	}
+	widths := [9]int{16, 80, 8, 8, 8, 8, 8, 80, 8}
+	for f, width := range widths {
+		for ch := 0; ch < numChannels; ch++ {
+			if _, err := bw.w.Write(padRight(fields[f][ch], width)); err != nil {
+				return err
+			}
+		}
+	}
+	for ch := 0; ch < numChannels; ch++ {
+		if _, err := bw.w.Write(padRight("", 32)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func padRight(s string, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ' '
+	}
+	copy(b, s)
+	return b
+}
+
+// Write appends pkt's channel samples to the current data record,
+// flushing a full one-second record to the underlying writer once
+// cfg.SampleRate samples have been buffered.
+func (bw *BDFWriter) Write(pkt openbci.Packet) error {
+	for ch := 0; ch < numChannels; ch++ {
+		bw.buf[ch] = append(bw.buf[ch], pkt.Channels[ch])
+	}
+	bw.nBuffered++
+	if bw.nBuffered < bw.cfg.SampleRate {
+		return nil
+	}
+	return bw.flushRecord()
+}
+
+// Send implements stream.Sink so a BDFWriter can be used anywhere a Sink
+// is expected.
+func (bw *BDFWriter) Send(pkt openbci.Packet) error { return bw.Write(pkt) }
+
+func (bw *BDFWriter) flushRecord() error {
+	for ch := 0; ch < numChannels; ch++ {
+		for _, sample := range bw.buf[ch] {
+			if _, err := bw.w.Write(encode24LE(sample)); err != nil {
+				return err
+			}
+		}
+		bw.buf[ch] = bw.buf[ch][:0]
+	}
+	bw.nBuffered = 0
+	bw.nRecords++
+	return nil
+}
+
+func encode24LE(v int32) []byte {
+	return []byte{byte(v), byte(v >> 8), byte(v >> 16)}
+}
+
+// Close flushes any partially filled final data record, padding with
+// zeros, and patches the header's record count if the underlying writer
+// supports seeking.
+func (bw *BDFWriter) Close() error {
+	if bw.nBuffered > 0 {
+		pad := bw.cfg.SampleRate - bw.nBuffered
+		for ch := 0; ch < numChannels; ch++ {
+			for i := 0; i < pad; i++ {
+				bw.buf[ch] = append(bw.buf[ch], 0)
+			}
+		}
+		bw.nBuffered = bw.cfg.SampleRate
+		if err := bw.flushRecord(); err != nil {
+			return err
+		}
+	}
+	ws, ok := bw.w.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	if _, err := ws.Seek(236, io.SeekStart); err != nil {
+		return err
+	}
+	_, err := ws.Write(padRight(fmt.Sprintf("%d", bw.nRecords), 8))
+	return err
+}
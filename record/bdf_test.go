@@ -0,0 +1,188 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package record
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/kevinjos/openbci-driver"
+)
+
+func testConfig() Config {
+	return Config{
+		PatientID:   "P1",
+		RecordingID: "R1",
+		StartTime:   time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC),
+		SampleRate:  2,
+		PhysicalMin: -100,
+		PhysicalMax: 100,
+		Prefilter:   "HP:0.1Hz",
+	}
+}
+
+func TestBDFWriterHeader(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := testConfig()
+	if _, err := NewBDFWriter(&buf, cfg); err != nil {
+		t.Fatalf("NewBDFWriter: %v", err)
+	}
+
+	if got, want := buf.Len(), mainHdrBytes+numChannels*sigHdrBytes; got != want {
+		t.Fatalf("header length = %d, want %d", got, want)
+	}
+	main := buf.Bytes()[:mainHdrBytes]
+
+	if main[0] != 0xFF {
+		t.Errorf("main[0] = %#x, want 0xFF", main[0])
+	}
+	if got := string(main[1:8]); got != "BIOSEMI" {
+		t.Errorf("main[1:8] = %q, want %q", got, "BIOSEMI")
+	}
+	if got := string(bytes.TrimRight(main[8:88], " ")); got != cfg.PatientID {
+		t.Errorf("PatientID field = %q, want %q", got, cfg.PatientID)
+	}
+	if got := string(bytes.TrimRight(main[88:168], " ")); got != cfg.RecordingID {
+		t.Errorf("RecordingID field = %q, want %q", got, cfg.RecordingID)
+	}
+	if got, want := string(main[168:176]), cfg.StartTime.Format("02.01.06"); got != want {
+		t.Errorf("start date field = %q, want %q", got, want)
+	}
+	if got, want := string(main[176:184]), cfg.StartTime.Format("15.04.05"); got != want {
+		t.Errorf("start time field = %q, want %q", got, want)
+	}
+	wantHdrLen := fmt.Sprintf("%d", mainHdrBytes+numChannels*sigHdrBytes)
+	if got := string(bytes.TrimRight(main[184:192], " ")); got != wantHdrLen {
+		t.Errorf("header record length field = %q, want %q", got, wantHdrLen)
+	}
+	if got := string(bytes.TrimRight(main[192:236], " ")); got != "BDF+C" {
+		t.Errorf("continuity marker = %q, want %q", got, "BDF+C")
+	}
+	if got := string(bytes.TrimRight(main[236:244], " ")); got != "-1" {
+		t.Errorf("data record count placeholder = %q, want %q", got, "-1")
+	}
+	if got := string(bytes.TrimRight(main[244:252], " ")); got != "1" {
+		t.Errorf("data record duration = %q, want %q", got, "1")
+	}
+	wantChans := fmt.Sprintf("%d", numChannels)
+	if got := string(bytes.TrimRight(main[252:256], " ")); got != wantChans {
+		t.Errorf("channel count field = %q, want %q", got, wantChans)
+	}
+
+	// Field 3 (digital minimum's predecessor, physical minimum) starts
+	// after label (16), transducer type (80), and unit (8), each written
+	// numChannels at a time.
+	sig := buf.Bytes()[mainHdrBytes:]
+	if label := string(bytes.TrimRight(sig[0:16], " ")); label != "ch0" {
+		t.Errorf("channel 0 label = %q, want %q", label, "ch0")
+	}
+	physMinOff := (16 + 80 + 8) * numChannels
+	wantPhysMin := fmt.Sprintf("%g", cfg.PhysicalMin)
+	if got := string(bytes.TrimRight(sig[physMinOff:physMinOff+8], " ")); got != wantPhysMin {
+		t.Errorf("channel 0 physical min = %q, want %q", got, wantPhysMin)
+	}
+}
+
+// decode24LE is the inverse of encode24LE, used only to verify round-tripped
+// samples in these tests.
+func decode24LE(b0, b1, b2 byte) int32 {
+	v := int32(b0) | int32(b1)<<8 | int32(b2)<<16
+	if v&0x800000 != 0 {
+		v |= -1 << 24
+	}
+	return v
+}
+
+func TestBDFWriterRoundTripsSample(t *testing.T) {
+	var buf bytes.Buffer
+	cfg := testConfig() // SampleRate: 2, so two Writes fill one data record
+
+	bw, err := NewBDFWriter(&buf, cfg)
+	if err != nil {
+		t.Fatalf("NewBDFWriter: %v", err)
+	}
+
+	const wantSample = int32(-12345)
+	var pkt openbci.Packet
+	pkt.Channels[3] = wantSample
+	if err := bw.Write(pkt); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := bw.Write(pkt); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+
+	data := buf.Bytes()[mainHdrBytes+numChannels*sigHdrBytes:]
+	// Data records are channel-major: channel 3's two samples start after
+	// channels 0-2's samples, each sample 3 bytes wide.
+	off := 3 * cfg.SampleRate * 3
+	got := decode24LE(data[off], data[off+1], data[off+2])
+	if got != wantSample {
+		t.Errorf("round-tripped channel 3 sample = %d, want %d", got, wantSample)
+	}
+}
+
+// seekBuffer is a minimal io.WriteSeeker backed by an in-memory slice, used
+// to exercise the record-count patch BDFWriter.Close makes when the
+// underlying writer supports seeking.
+type seekBuffer struct {
+	data []byte
+	pos  int
+}
+
+func (s *seekBuffer) Write(p []byte) (int, error) {
+	if end := s.pos + len(p); end > len(s.data) {
+		grown := make([]byte, end)
+		copy(grown, s.data)
+		s.data = grown
+	}
+	copy(s.data[s.pos:], p)
+	s.pos += len(p)
+	return len(p), nil
+}
+
+func (s *seekBuffer) Seek(offset int64, whence int) (int64, error) {
+	if whence != io.SeekStart {
+		return 0, fmt.Errorf("seekBuffer: unsupported whence %d", whence)
+	}
+	s.pos = int(offset)
+	return offset, nil
+}
+
+func TestBDFWriterPatchesRecordCountOnClose(t *testing.T) {
+	sb := &seekBuffer{}
+	cfg := testConfig() // SampleRate: 2
+
+	bw, err := NewBDFWriter(sb, cfg)
+	if err != nil {
+		t.Fatalf("NewBDFWriter: %v", err)
+	}
+	if err := bw.Write(openbci.Packet{}); err != nil { // one sample: a partial record
+		t.Fatalf("Write: %v", err)
+	}
+	if err := bw.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := string(bytes.TrimRight(sb.data[236:244], " ")); got != "1" {
+		t.Errorf("data record count = %q, want %q", got, "1")
+	}
+}
@@ -0,0 +1,59 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package record
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/kevinjos/openbci-driver"
+)
+
+// CSVWriter writes decoded Packets as gzip-compressed CSV rows: sequence
+// number, the 8 channel values, and the 3 aux values.
+type CSVWriter struct {
+	gz *gzip.Writer
+}
+
+// NewCSVWriter wraps w in a gzip.Writer and writes a CSV header row.
+func NewCSVWriter(w io.Writer) (*CSVWriter, error) {
+	gz := gzip.NewWriter(w)
+	if _, err := io.WriteString(gz, "seq,ch0,ch1,ch2,ch3,ch4,ch5,ch6,ch7,aux0,aux1,aux2\n"); err != nil {
+		return nil, err
+	}
+	return &CSVWriter{gz: gz}, nil
+}
+
+// Write appends pkt as a CSV row.
+func (c *CSVWriter) Write(pkt openbci.Packet) error {
+	_, err := fmt.Fprintf(c.gz, "%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d,%d\n",
+		pkt.SeqNum,
+		pkt.Channels[0], pkt.Channels[1], pkt.Channels[2], pkt.Channels[3],
+		pkt.Channels[4], pkt.Channels[5], pkt.Channels[6], pkt.Channels[7],
+		pkt.AuxData[0], pkt.AuxData[1], pkt.AuxData[2],
+	)
+	return err
+}
+
+// Send implements stream.Sink so a CSVWriter can be used anywhere a Sink
+// is expected.
+func (c *CSVWriter) Send(pkt openbci.Packet) error { return c.Write(pkt) }
+
+// Close flushes and closes the gzip stream.
+func (c *CSVWriter) Close() error { return c.gz.Close() }
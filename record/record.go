@@ -0,0 +1,36 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package record turns a decoded openbci.Packet stream into industry
+// standard EEG files: BDF+ (the 24-bit variant of EDF+) and gzip'd CSV.
+// There is no writer for 16-bit EDF+ files.
+package record
+
+import "time"
+
+// Config describes the header fields and batching behavior of a
+// BDFWriter. CSVWriter takes no Config.
+type Config struct {
+	PatientID   string
+	RecordingID string
+	StartTime   time.Time
+	SampleRate  int     // samples per second per channel, e.g. 250
+	PhysicalMin float64 // microvolts
+	PhysicalMax float64 // microvolts
+	Prefilter   string
+}
@@ -0,0 +1,156 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// defaultResetTimeout bounds a reset triggered internally by Write, i.e.
+// when isReset recognizes Command["reset"] in the written bytes. Callers
+// that want a shorter bound should call ResetContext directly; the banner
+// wait is capped at bannerTimeout however long the caller's ctx runs.
+const defaultResetTimeout = 5 * time.Second
+
+// bannerTimeout bounds how long a single expect call will wait for the
+// board's "$$$" banner once the reset and stop bytes have been sent.
+const bannerTimeout = 4 * time.Second
+
+// ErrResetTimeout is returned by ResetContext when ctx expires or
+// bannerTimeout elapses before the board acknowledges the reset.
+var ErrResetTimeout = errors.New("openbci: reset timed out waiting for board")
+
+// BoardInfo describes the firmware banner a Cyton board sends on startup,
+// e.g. "OpenBCI V3 16-channel\n...$$$".
+type BoardInfo struct {
+	Version     string
+	NumChannels int
+	Banner      string
+}
+
+// BoardInfo returns the board info parsed during the most recent
+// successful ResetContext call, or the zero value if none has completed
+// yet.
+func (d *Device) BoardInfo() BoardInfo { return d.info }
+
+// ResetContext stops the board, issues a reset, and waits for its "$$$"
+// startup banner, racing the read loop against ctx. It returns
+// ErrResetTimeout if ctx expires, or bannerTimeout elapses, before the
+// banner arrives. On success it restarts streaming
+// and records the parsed banner, available afterward via BoardInfo.
+func (d *Device) ResetContext(ctx context.Context) error {
+	if _, err := d.writeRaw([]byte{Command["stop"]}); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	if _, err := d.writeRaw([]byte{Command["reset"]}); err != nil {
+		return err
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	banner, err := d.expect(ctx, []byte{Command["init"], Command["init"], Command["init"]}, bannerTimeout)
+	if err != nil {
+		return err
+	}
+	d.info = parseBanner(string(banner))
+
+	_, err = d.writeRaw([]byte{Command["start"]})
+	return err
+}
+
+// expect reads from the device a byte at a time, accumulating them until
+// the trailing bytes match pattern, ctx is done, or deadline elapses -
+// whichever comes first. It returns the bytes read so far in either case,
+// so a caller can inspect a partial banner on timeout. Other command
+// handshakes reuse this to wait on their own reply patterns, as
+// ConfigureChannel and MeasureImpedance do for the board's "$$$" ack.
+//
+// Each read runs on its own goroutine so it can be raced against
+// stepCtx.Done() even when the underlying Transport has no read deadline
+// of its own (TCPTransport and FIFOTransport never call SetDeadline, and
+// SerialTransport may be configured with a long or zero ReadTimeout). If
+// stepCtx expires while a read is in flight, that read is abandoned
+// rather than waited on - it may still be blocked inside the transport
+// when this returns, and its goroutine exits whenever the transport
+// eventually unblocks it (or never, for a transport with no timeout at
+// all). That's a deliberate trade: a leaked goroutine per abandoned read
+// is preferable to expect itself hanging past the caller's deadline.
+func (d *Device) expect(ctx context.Context, pattern []byte, deadline time.Duration) ([]byte, error) {
+	stepCtx, cancel := context.WithTimeout(ctx, deadline)
+	defer cancel()
+
+	type readResult struct {
+		b   byte
+		n   int
+		err error
+	}
+
+	var buf []byte
+	for {
+		resultCh := make(chan readResult, 1)
+		go func() {
+			scratch := make([]byte, 1)
+			n, err := d.Read(scratch)
+			resultCh <- readResult{b: scratch[0], n: n, err: err}
+		}()
+
+		select {
+		case <-stepCtx.Done():
+			return buf, ErrResetTimeout
+		case res := <-resultCh:
+			if res.err == io.EOF || res.n == 0 {
+				continue
+			}
+			if res.err != nil {
+				return buf, res.err
+			}
+			buf = append(buf, res.b)
+			if bytes.HasSuffix(buf, pattern) {
+				return buf, nil
+			}
+		}
+	}
+}
+
+var (
+	bannerVersionRe  = regexp.MustCompile(`V(\d+)`)
+	bannerChannelsRe = regexp.MustCompile(`(\d+)[\s-]*channel`)
+)
+
+// parseBanner extracts the firmware version and channel count from a raw
+// "OpenBCI V3 8-16 channel ... $$$" startup banner. Fields that don't
+// match are left at their zero value; Banner always holds the raw text.
+func parseBanner(banner string) BoardInfo {
+	info := BoardInfo{Banner: banner}
+	if m := bannerVersionRe.FindStringSubmatch(banner); m != nil {
+		info.Version = "V" + m[1]
+	}
+	if m := bannerChannelsRe.FindStringSubmatch(banner); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil {
+			info.NumChannels = n
+		}
+	}
+	return info
+}
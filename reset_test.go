@@ -0,0 +1,89 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// newMockHarness wires a Device directly to a MockDevice, the same way
+// NewDevice would via a Transport, without needing Transport.Open.
+func newMockHarness() (*Device, *MockDevice) {
+	md := NewMockDevice()
+	return &Device{r: md, w: md, c: md}, md
+}
+
+func TestResetContextSuccess(t *testing.T) {
+	dev, _ := newMockHarness()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := dev.ResetContext(ctx); err != nil {
+		t.Fatalf("ResetContext: %v", err)
+	}
+
+	info := dev.BoardInfo()
+	if info.Version != "V3" {
+		t.Errorf("BoardInfo().Version = %q, want %q", info.Version, "V3")
+	}
+	if info.NumChannels != 16 {
+		t.Errorf("BoardInfo().NumChannels = %d, want 16", info.NumChannels)
+	}
+
+	// ResetContext ends by sending "start", so the device should now be
+	// streaming SDK frames rather than banner bytes.
+	sc := NewScanner(dev)
+	if !sc.Scan() {
+		t.Fatalf("Scan() after reset: %v", sc.Err())
+	}
+}
+
+// silentDevice never produces a byte, so anything waiting on it times out.
+type silentDevice struct{}
+
+func (silentDevice) Read(p []byte) (int, error)  { return 0, nil }
+func (silentDevice) Write(p []byte) (int, error) { return len(p), nil }
+func (silentDevice) Close() error                { return nil }
+
+func TestResetContextTimeout(t *testing.T) {
+	dev := &Device{r: silentDevice{}, w: silentDevice{}, c: silentDevice{}}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := dev.ResetContext(ctx)
+	if !errors.Is(err, ErrResetTimeout) {
+		t.Fatalf("ResetContext() error = %v, want ErrResetTimeout", err)
+	}
+}
+
+func TestParseBanner(t *testing.T) {
+	banner := "OpenBCI V3 8-16 channel\n$$$"
+	info := parseBanner(banner)
+	if info.Version != "V3" {
+		t.Errorf("Version = %q, want V3", info.Version)
+	}
+	if info.NumChannels != 16 {
+		t.Errorf("NumChannels = %d, want 16", info.NumChannels)
+	}
+	if info.Banner != banner {
+		t.Errorf("Banner = %q, want the original text preserved", info.Banner)
+	}
+}
@@ -0,0 +1,96 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package stream
+
+// #cgo LDFLAGS: -llsl
+// #include <lsl_c.h>
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/kevinjos/openbci-driver"
+)
+
+// LSLOutlet publishes decoded Packets on a Lab Streaming Layer outlet so
+// EEGLAB, BCILAB, and other LSL-aware tools can consume them directly.
+type LSLOutlet struct {
+	info       C.lsl_streaminfo
+	outlet     C.lsl_outlet
+	nChannels  int
+	sampleRate float64
+
+	seq      seqAccumulator
+	haveBase bool
+	baseTime C.double
+}
+
+// NewLSLOutlet creates and advertises an LSL outlet named name, streaming
+// nChannels channels of EEG data at sampleRate Hz.
+func NewLSLOutlet(name string, nChannels int, sampleRate float64) (*LSLOutlet, error) {
+	cName := C.CString(name)
+	defer C.free(unsafe.Pointer(cName))
+	cType := C.CString("EEG")
+	defer C.free(unsafe.Pointer(cType))
+	cSourceID := C.CString(name + "-openbci")
+	defer C.free(unsafe.Pointer(cSourceID))
+
+	info := C.lsl_create_streaminfo(cName, cType, C.int(nChannels), C.double(sampleRate), C.cf_float32, cSourceID)
+	if info == nil {
+		return nil, fmt.Errorf("stream: failed to create LSL stream info for %q", name)
+	}
+	outlet := C.lsl_create_outlet(info, 0, 360)
+	if outlet == nil {
+		C.lsl_destroy_streaminfo(info)
+		return nil, fmt.Errorf("stream: failed to create LSL outlet for %q", name)
+	}
+	return &LSLOutlet{info: info, outlet: outlet, nChannels: nChannels, sampleRate: sampleRate}, nil
+}
+
+// Send pushes pkt's channel data onto the outlet. The outgoing LSL
+// timestamp is extrapolated from the device's own sequence number rather
+// than wall-clock time, so a downstream consumer can correct for jitter
+// introduced between the board and this process. The sequence number
+// itself wraps every 256 packets, so Send tracks it through a
+// seqAccumulator, which folds the per-packet deltas into a monotonically
+// increasing sample count rather than diffing against a single fixed
+// base, which would make the timestamp saw backwards once SeqNum wrapped.
+func (o *LSLOutlet) Send(pkt openbci.Packet) error {
+	if !o.haveBase {
+		o.baseTime = C.lsl_local_clock()
+		o.haveBase = true
+	}
+	totalSamples := o.seq.Advance(pkt.SeqNum)
+	elapsed := float64(totalSamples) / o.sampleRate
+	ts := o.baseTime + C.double(elapsed)
+
+	samples := make([]C.float, o.nChannels)
+	for i := 0; i < o.nChannels && i < len(pkt.Channels); i++ {
+		samples[i] = C.float(pkt.Channels[i])
+	}
+	C.lsl_push_sample_ft(o.outlet, (*C.float)(unsafe.Pointer(&samples[0])), ts)
+	return nil
+}
+
+// Close destroys the underlying LSL outlet and stream info.
+func (o *LSLOutlet) Close() error {
+	C.lsl_destroy_outlet(o.outlet)
+	C.lsl_destroy_streaminfo(o.info)
+	return nil
+}
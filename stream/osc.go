@@ -0,0 +1,64 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package stream
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/hypebeast/go-osc/osc"
+	"github.com/kevinjos/openbci-driver"
+)
+
+// OSCSink republishes decoded Packets as OSC/UDP messages, for realtime
+// art and music tools that speak OSC rather than LSL.
+type OSCSink struct {
+	client  *osc.Client
+	address string
+}
+
+// NewOSCSink dials a UDP OSC client targeting addr (host:port) and returns
+// a Sink that sends each Packet's channel data as an OSC message under
+// oscAddress, e.g. "/openbci/eeg".
+func NewOSCSink(addr, oscAddress string) (*OSCSink, error) {
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("stream: invalid OSC target %q: %v", addr, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return nil, fmt.Errorf("stream: invalid OSC target port %q: %v", portStr, err)
+	}
+	return &OSCSink{client: osc.NewClient(host, port), address: oscAddress}, nil
+}
+
+// Send encodes pkt's sequence number and channel data as an OSC message
+// and sends it to the configured target.
+func (s *OSCSink) Send(pkt openbci.Packet) error {
+	msg := osc.NewMessage(s.address)
+	msg.Append(int32(pkt.SeqNum))
+	for _, ch := range pkt.Channels {
+		msg.Append(float32(ch))
+	}
+	return s.client.Send(msg)
+}
+
+// Close is a no-op; OSCSink holds no resources beyond the UDP socket,
+// which go-osc manages per send.
+func (s *OSCSink) Close() error { return nil }
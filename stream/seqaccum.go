@@ -0,0 +1,42 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package stream
+
+// seqAccumulator turns a stream of openbci.Packet.SeqNum values, which wrap
+// every 256 packets, into a monotonically increasing sample count. A
+// timestamp derived by dividing this count by the sample rate stays
+// monotonic across the wrap, unlike one diffed against a single fixed base
+// sequence number.
+type seqAccumulator struct {
+	have  bool
+	prev  uint8
+	total uint64
+}
+
+// Advance folds seq into the running total and returns the updated count.
+// The first call establishes the base sequence number and returns 0.
+func (a *seqAccumulator) Advance(seq uint8) uint64 {
+	if !a.have {
+		a.prev = seq
+		a.have = true
+		return a.total
+	}
+	a.total += uint64(seq - a.prev)
+	a.prev = seq
+	return a.total
+}
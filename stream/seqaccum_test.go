@@ -0,0 +1,49 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package stream
+
+import "testing"
+
+func TestSeqAccumulatorFirstAdvanceEstablishesBase(t *testing.T) {
+	var a seqAccumulator
+	if got := a.Advance(42); got != 0 {
+		t.Errorf("Advance(42) on empty accumulator = %d, want 0", got)
+	}
+}
+
+func TestSeqAccumulatorAccumulatesDeltas(t *testing.T) {
+	var a seqAccumulator
+	a.Advance(10)
+	if got := a.Advance(12); got != 2 {
+		t.Errorf("Advance(12) after base 10 = %d, want 2", got)
+	}
+	if got := a.Advance(15); got != 5 {
+		t.Errorf("Advance(15) after 12 = %d, want 5", got)
+	}
+}
+
+func TestSeqAccumulatorWrapsMonotonically(t *testing.T) {
+	var a seqAccumulator
+	seqs := []uint8{253, 254, 255, 0, 1, 2}
+	want := []uint64{0, 1, 2, 3, 4, 5}
+	for i, seq := range seqs {
+		if got := a.Advance(seq); got != want[i] {
+			t.Errorf("Advance(%d) = %d, want %d", seq, got, want[i])
+		}
+	}
+}
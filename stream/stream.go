@@ -0,0 +1,33 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+
+// Package stream republishes decoded openbci.Packets over standard
+// neuroscience and IoT transports so downstream tools don't need to speak
+// the Cyton wire protocol.
+package stream
+
+import "github.com/kevinjos/openbci-driver"
+
+// Sink accepts decoded packets and forwards them to some external
+// destination. Implementations in this package include an LSL outlet and
+// an OSC/UDP sink; callers that want to fan a stream out to several
+// destinations can hold a []Sink and call Send on each.
+type Sink interface {
+	Send(pkt openbci.Packet) error
+	Close() error
+}
@@ -0,0 +1,182 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+// defaultReadTimeout is used by a serial transport URL that omits the
+// readtimeout query parameter.
+const defaultReadTimeout = 500 * time.Millisecond
+
+// Transport opens a connection to an OpenBCI board. Once Open returns nil
+// the Transport behaves like any other byte stream until Close is called.
+// NewDevice accepts any type satisfying this interface, so a Device can run
+// over serial, TCP, a FIFO, or an in-process pipe for tests without
+// changing any driver code.
+type Transport interface {
+	Open() error
+	io.ReadWriteCloser
+}
+
+// SerialTransport opens a local serial port, e.g. /dev/ttyUSB0 or COM3.
+type SerialTransport struct {
+	Location    string
+	Baud        int
+	ReadTimeout time.Duration
+
+	conn io.ReadWriteCloser
+}
+
+// NewSerialTransport returns a Transport that dials a local serial port
+// when opened.
+func NewSerialTransport(location string, baud int, readTimeout time.Duration) *SerialTransport {
+	return &SerialTransport{Location: location, Baud: baud, ReadTimeout: readTimeout}
+}
+
+func (t *SerialTransport) Open() error {
+	conn, err := serial.OpenPort(&serial.Config{
+		Name:        t.Location,
+		Baud:        t.Baud,
+		ReadTimeout: t.ReadTimeout,
+	})
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	return nil
+}
+
+func (t *SerialTransport) Read(p []byte) (int, error)  { return t.conn.Read(p) }
+func (t *SerialTransport) Write(p []byte) (int, error) { return t.conn.Write(p) }
+func (t *SerialTransport) Close() error                { return t.conn.Close() }
+
+// TCPTransport dials a networked bridge, e.g. the OpenBCI WiFi shield.
+type TCPTransport struct {
+	Addr string
+
+	conn net.Conn
+}
+
+// NewTCPTransport returns a Transport that dials addr over TCP when opened.
+func NewTCPTransport(addr string) *TCPTransport {
+	return &TCPTransport{Addr: addr}
+}
+
+func (t *TCPTransport) Open() error {
+	conn, err := net.Dial("tcp", t.Addr)
+	if err != nil {
+		return err
+	}
+	t.conn = conn
+	return nil
+}
+
+func (t *TCPTransport) Read(p []byte) (int, error)  { return t.conn.Read(p) }
+func (t *TCPTransport) Write(p []byte) (int, error) { return t.conn.Write(p) }
+func (t *TCPTransport) Close() error                { return t.conn.Close() }
+
+// FIFOTransport reads and writes a Unix named pipe, useful for replaying a
+// recorded session or driving the driver without real hardware. The pipe
+// must already exist, e.g. created with mkfifo.
+type FIFOTransport struct {
+	Path string
+
+	file *os.File
+}
+
+// NewFIFOTransport returns a Transport that opens the named pipe at path
+// when opened.
+func NewFIFOTransport(path string) *FIFOTransport {
+	return &FIFOTransport{Path: path}
+}
+
+func (t *FIFOTransport) Open() error {
+	// O_RDWR keeps the open from blocking on a peer, the same trick the Go
+	// standard library uses to exercise FIFOs in its own os package tests.
+	file, err := os.OpenFile(t.Path, os.O_RDWR, os.ModeNamedPipe)
+	if err != nil {
+		return err
+	}
+	t.file = file
+	return nil
+}
+
+func (t *FIFOTransport) Read(p []byte) (int, error)  { return t.file.Read(p) }
+func (t *FIFOTransport) Write(p []byte) (int, error) { return t.file.Write(p) }
+func (t *FIFOTransport) Close() error                { return t.file.Close() }
+
+// NewDeviceFromURL builds a Device from the first of the given transport
+// URLs that can be parsed and opened successfully, trying each in order.
+// This lets callers list a preferred backend with fallbacks, e.g.
+// NewDeviceFromURL("serial:///dev/ttyUSB0?baud=115200", "tcp://192.168.4.1:3000").
+// Supported schemes are serial://<path>?baud=<n>[&readtimeout=<ms>],
+// tcp://<host:port>, and fifo://<path>.
+func NewDeviceFromURL(urls ...string) (io.ReadWriteCloser, error) {
+	var err error
+	for _, raw := range urls {
+		var t Transport
+		if t, err = transportFromURL(raw); err != nil {
+			continue
+		}
+		var dev io.ReadWriteCloser
+		if dev, err = NewDevice(t); err != nil {
+			continue
+		}
+		return dev, nil
+	}
+	return nil, fmt.Errorf("openbci: no transport URL could be opened: %v", err)
+}
+
+func transportFromURL(raw string) (Transport, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "serial":
+		baud, err := strconv.Atoi(u.Query().Get("baud"))
+		if err != nil {
+			return nil, fmt.Errorf("openbci: serial URL %q missing valid baud query param", raw)
+		}
+		readTimeout := defaultReadTimeout
+		if rt := u.Query().Get("readtimeout"); rt != "" {
+			ms, err := strconv.Atoi(rt)
+			if err != nil {
+				return nil, fmt.Errorf("openbci: serial URL %q has invalid readtimeout query param", raw)
+			}
+			readTimeout = time.Duration(ms) * time.Millisecond
+		}
+		return NewSerialTransport(u.Path, baud, readTimeout), nil
+	case "tcp":
+		return NewTCPTransport(u.Host), nil
+	case "fifo":
+		return NewFIFOTransport(u.Path), nil
+	default:
+		return nil, fmt.Errorf("openbci: unsupported transport scheme %q", u.Scheme)
+	}
+}
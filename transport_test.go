@@ -0,0 +1,105 @@
+/*  OpenBCI golang server allows users to control, visualize and store data
+    collected from the OpenBCI microcontroller.
+    Copyright (C) 2015  Kevin Schiesser
+
+    This program is free software: you can redistribute it and/or modify
+    it under the terms of the GNU Affero General Public License as
+    published by the Free Software Foundation, either version 3 of the
+    License, or (at your option) any later version.
+
+    This program is distributed in the hope that it will be useful,
+    but WITHOUT ANY WARRANTY; without even the implied warranty of
+    MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+    GNU Affero General Public License for more details.
+
+    You should have received a copy of the GNU Affero General Public License
+    along with this program.  If not, see <http://www.gnu.org/licenses/>.
+*/
+package openbci
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// pipeTransport adapts an already-connected net.Conn, such as one half of
+// net.Pipe, to the Transport interface. It stands in for FIFOTransport in
+// tests: both let the driver be exercised over an in-process byte stream
+// with no real hardware involved.
+type pipeTransport struct {
+	net.Conn
+}
+
+func (p pipeTransport) Open() error { return nil }
+
+func TestNewDeviceOverInProcessPipe(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+
+	dev, err := NewDevice(pipeTransport{client})
+	if err != nil {
+		t.Fatalf("NewDevice: %v", err)
+	}
+	defer dev.Close()
+
+	go func() {
+		req := make([]byte, 1)
+		if _, err := server.Read(req); err != nil {
+			return
+		}
+		server.Write([]byte{0x2a})
+	}()
+
+	if _, err := dev.Write([]byte{0x01}); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	resp := make([]byte, 1)
+	n, err := dev.Read(resp)
+	if err != nil || n != 1 || resp[0] != 0x2a {
+		t.Fatalf("Read() = %d, %v, %v; want 1 byte 0x2a, nil", n, resp, err)
+	}
+}
+
+func TestTransportFromURL(t *testing.T) {
+	cases := []struct {
+		url     string
+		wantErr bool
+	}{
+		{"serial:///dev/ttyUSB0?baud=115200", false},
+		{"serial:///dev/ttyUSB0", true}, // missing baud
+		{"tcp://192.168.4.1:3000", false},
+		{"fifo:///tmp/bci.raw", false},
+		{"carrier-pigeon://nope", true}, // unsupported scheme
+	}
+	for _, c := range cases {
+		tr, err := transportFromURL(c.url)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("transportFromURL(%q): want error, got none", c.url)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("transportFromURL(%q): unexpected error: %v", c.url, err)
+			continue
+		}
+		if tr == nil {
+			t.Errorf("transportFromURL(%q): got nil Transport", c.url)
+		}
+	}
+}
+
+func TestTransportFromURLSerialFields(t *testing.T) {
+	tr, err := transportFromURL("serial:///dev/ttyUSB0?baud=115200&readtimeout=250")
+	if err != nil {
+		t.Fatalf("transportFromURL: %v", err)
+	}
+	st, ok := tr.(*SerialTransport)
+	if !ok {
+		t.Fatalf("transportFromURL: got %T, want *SerialTransport", tr)
+	}
+	if st.Location != "/dev/ttyUSB0" || st.Baud != 115200 || st.ReadTimeout != 250*time.Millisecond {
+		t.Errorf("SerialTransport = %+v, want Location=/dev/ttyUSB0 Baud=115200 ReadTimeout=250ms", st)
+	}
+}